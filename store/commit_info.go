@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 )
 
@@ -15,6 +16,22 @@ type (
 		StoreInfos []StoreInfo
 		Timestamp  time.Time
 		CommitHash []byte
+
+		// Hasher is the hash function used to build the commitment tree in Hash
+		// and GetStoreProof. It is runtime configuration, not part of the wire
+		// format, and defaults to DefaultHasher (SHA-256) when nil.
+		Hasher Hasher
+
+		// merkleTree lazily caches the simple-merkle tree built over
+		// StoreInfos, so Hash and repeated GetStoreProof calls share the work
+		// of sorting, leaf-hashing and inner-node hashing. It is invalidated
+		// automatically if StoreInfos changes. Not part of the wire format.
+		// merkleTreeMu guards both merkleTree and the sort of StoreInfos that
+		// precedes it, since concurrent Hash/GetStoreProof calls on the same
+		// CommitInfo (e.g. query handling for the same committed version)
+		// would otherwise race on them.
+		merkleTreeMu sync.Mutex
+		merkleTree   *merkleTreeCache
 	}
 
 	// StoreInfo defines store-specific commit information. It contains a reference
@@ -36,8 +53,19 @@ func (si StoreInfo) GetHash() []byte {
 	return si.CommitID.Hash
 }
 
+// hasher returns ci.Hasher, falling back to DefaultHasher when unset.
+func (ci *CommitInfo) hasher() Hasher {
+	if ci.Hasher != nil {
+		return ci.Hasher
+	}
+	return DefaultHasher
+}
+
 // Hash returns the root hash of all committed stores represented by CommitInfo,
-// sorted by store name/key.
+// sorted by store name/key. It is computed using ci.Hasher, or DefaultHasher
+// (SHA-256) if unset. The underlying tree is cached on ci, so calling Hash
+// again (or calling GetStoreProof afterwards) for the same StoreInfos reuses
+// the work instead of rebuilding it.
 func (ci *CommitInfo) Hash() []byte {
 	if len(ci.StoreInfos) == 0 {
 		return nil
@@ -47,8 +75,11 @@ func (ci *CommitInfo) Hash() []byte {
 		return ci.CommitHash
 	}
 
-	rootHash, _, _ := ci.GetStoreProof("")
-	return rootHash
+	tree, err := ci.cachedTree()
+	if err != nil || tree.root == nil {
+		return nil
+	}
+	return tree.root.hash
 }
 
 // GetStoreCommitID returns the CommitID for the given store key.
@@ -62,33 +93,38 @@ func (ci *CommitInfo) GetStoreCommitID(storeKey string) CommitID {
 }
 
 // GetStoreProof returns the simple merkle proof for the given store key. It will
-// return the merkle root hash of all committed stores.
+// return the merkle root hash of all committed stores. The proof is built (and
+// must be verified) using ci.Hasher, or DefaultHasher (SHA-256) if unset.
+//
+// The underlying tree is cached on ci (see merkleTreeCache), so consecutive
+// calls for different store keys at the same StoreInfos produce each proof
+// without re-hashing leaves or inner nodes: the proof path is walked in
+// O(log n), but every call still sorts StoreInfos and compares them with the
+// cached snapshot, which is O(n). It is safe to call
+// GetStoreProof (and Hash) concurrently on the same CommitInfo, as query
+// handling for a single committed version routinely does.
 func (ci *CommitInfo) GetStoreProof(storeKey string) ([]byte, *CommitmentOp, error) {
-	sort.Slice(ci.StoreInfos, func(i, j int) bool {
-		return ci.StoreInfos[i].Name < ci.StoreInfos[j].Name
-	})
-
-	index := 0
-	leaves := make([][]byte, len(ci.StoreInfos))
-	for i, si := range ci.StoreInfos {
-		var err error
-		leaves[i], err = LeafHash([]byte(si.Name), si.GetHash())
-		if err != nil {
-			return nil, nil, err
-		}
-		if si.Name == storeKey {
-			index = i
-		}
+	tree, err := ci.cachedTree()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	rootHash, inners := ProofFromByteSlices(leaves, index)
-	commitmentOp := ConvertCommitmentOp(inners, []byte(storeKey), ci.StoreInfos[index].GetHash())
-
-	return rootHash, &commitmentOp, nil
+	return tree.proof(storeKey)
 }
 
+const (
+	// commitInfoMagic prefixes every Marshal'd CommitInfo, guarding against
+	// decoding an unrelated byte stream as a CommitInfo.
+	commitInfoMagic byte = 0xC1
+	// commitInfoVersion is the current CommitInfo encoding format version.
+	commitInfoVersion byte = 1
+	// commitInfoHeaderSize is the size, in bytes, of the magic+version prefix.
+	commitInfoHeaderSize = 2
+)
+
 func (ci *CommitInfo) encodedSize() int {
-	size := EncodeUvarintSize(ci.Version)
+	size := commitInfoHeaderSize
+	size += EncodeUvarintSize(ci.Version)
 	size += EncodeVarintSize(ci.Timestamp.UnixNano())
 	size += EncodeUvarintSize(uint64(len(ci.StoreInfos)))
 	for _, storeInfo := range ci.StoreInfos {
@@ -98,10 +134,23 @@ func (ci *CommitInfo) encodedSize() int {
 	return size
 }
 
+// Marshal encodes CommitInfo into its canonical byte representation: a
+// magic+version header followed by the fields with StoreInfos sorted by name.
+// Sorting here (rather than trusting caller order) and the header make the
+// output deterministic and self-describing enough for an external verifier
+// (e.g. an EVM precompile) to decode and hash without knowing this package's
+// in-memory layout.
 func (ci *CommitInfo) Marshal() ([]byte, error) {
+	sort.Slice(ci.StoreInfos, func(i, j int) bool {
+		return ci.StoreInfos[i].Name < ci.StoreInfos[j].Name
+	})
+
 	var buf bytes.Buffer
 	buf.Grow(ci.encodedSize())
 
+	buf.WriteByte(commitInfoMagic)
+	buf.WriteByte(commitInfoVersion)
+
 	if err := EncodeUvarint(&buf, ci.Version); err != nil {
 		return nil, err
 	}
@@ -123,7 +172,25 @@ func (ci *CommitInfo) Marshal() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// CanonicalBytes returns the same bytes as Marshal: the deterministic,
+// self-describing encoding of CommitInfo that external light clients and
+// verifiers can hash directly to reproduce a commitment root.
+func (ci *CommitInfo) CanonicalBytes() ([]byte, error) {
+	return ci.Marshal()
+}
+
 func (ci *CommitInfo) Unmarshal(buf []byte) error {
+	if len(buf) < commitInfoHeaderSize {
+		return fmt.Errorf("commit info bytes too short to contain header: got %d bytes", len(buf))
+	}
+	if buf[0] != commitInfoMagic {
+		return fmt.Errorf("invalid commit info magic byte: got %#x, want %#x", buf[0], commitInfoMagic)
+	}
+	if buf[1] != commitInfoVersion {
+		return fmt.Errorf("unsupported commit info encoding version: %d", buf[1])
+	}
+	buf = buf[commitInfoHeaderSize:]
+
 	// Version
 	version, n, err := DecodeUvarint(buf)
 	if err != nil {
@@ -145,6 +212,7 @@ func (ci *CommitInfo) Unmarshal(buf []byte) error {
 	}
 	buf = buf[n:]
 	ci.StoreInfos = make([]StoreInfo, storeInfosLen)
+	seenNames := make(map[string]struct{}, storeInfosLen)
 	for i := 0; i < int(storeInfosLen); i++ {
 		// Name
 		name, n, err := DecodeBytes(buf)
@@ -152,6 +220,13 @@ func (ci *CommitInfo) Unmarshal(buf []byte) error {
 			return err
 		}
 		buf = buf[n:]
+		if _, ok := seenNames[string(name)]; ok {
+			return fmt.Errorf("duplicate store name in commit info: %s", name)
+		}
+		if i > 0 && string(name) < ci.StoreInfos[i-1].Name {
+			return fmt.Errorf("commit info store infos are not sorted by name: %q before %q", ci.StoreInfos[i-1].Name, name)
+		}
+		seenNames[string(name)] = struct{}{}
 		ci.StoreInfos[i].Name = string(name)
 		// CommitID
 		hash, n, err := DecodeBytes(buf)
@@ -168,6 +243,29 @@ func (ci *CommitInfo) Unmarshal(buf []byte) error {
 	return nil
 }
 
+// Verify checks that proof proves storeHash is committed under storeKey
+// within the multi-store root hash root. It is the one-call entry point an
+// external verifier (e.g. an EVM precompile) needs: it does not have to
+// separately inspect the CommitmentOp before running it.
+func (ci *CommitInfo) Verify(root []byte, storeKey string, storeHash []byte, proof *CommitmentOp) error {
+	if proof == nil {
+		return fmt.Errorf("nil commitment proof for store %q", storeKey)
+	}
+	if !bytes.Equal(proof.GetKey(), []byte(storeKey)) {
+		return fmt.Errorf("commitment proof key %q does not match store key %q", proof.GetKey(), storeKey)
+	}
+
+	result, err := proof.Run([][]byte{storeHash})
+	if err != nil {
+		return fmt.Errorf("could not verify commitment proof for store %q: %w", storeKey, err)
+	}
+	if len(result) != 1 || !bytes.Equal(result[0], root) {
+		return fmt.Errorf("commitment proof root does not match expected root for store %q", storeKey)
+	}
+
+	return nil
+}
+
 func (ci *CommitInfo) CommitID() CommitID {
 	return CommitID{
 		Version: ci.Version,
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+	"time"
+)
+
+func sampleCommitInfo() *CommitInfo {
+	h1 := sha256.Sum256([]byte("store-a"))
+	h2 := sha256.Sum256([]byte("store-b"))
+	return &CommitInfo{
+		Version: 3,
+		StoreInfos: []StoreInfo{
+			{Name: "a", CommitID: CommitID{Version: 3, Hash: h1[:]}},
+			{Name: "b", CommitID: CommitID{Version: 3, Hash: h2[:]}},
+		},
+		Timestamp: time.Unix(1700000000, 0).UTC(),
+	}
+}
+
+func TestCommitInfo_MarshalUnmarshalRoundTrip(t *testing.T) {
+	ci := sampleCommitInfo()
+
+	bz, err := ci.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got CommitInfo
+	if err := got.Unmarshal(bz); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if got.Version != ci.Version {
+		t.Fatalf("Version = %d, want %d", got.Version, ci.Version)
+	}
+	if !got.Timestamp.Equal(ci.Timestamp) {
+		t.Fatalf("Timestamp = %v, want %v", got.Timestamp, ci.Timestamp)
+	}
+	if len(got.StoreInfos) != len(ci.StoreInfos) {
+		t.Fatalf("StoreInfos len = %d, want %d", len(got.StoreInfos), len(ci.StoreInfos))
+	}
+	for i := range ci.StoreInfos {
+		if got.StoreInfos[i].Name != ci.StoreInfos[i].Name {
+			t.Fatalf("StoreInfos[%d].Name = %q, want %q", i, got.StoreInfos[i].Name, ci.StoreInfos[i].Name)
+		}
+		if !bytes.Equal(got.StoreInfos[i].CommitID.Hash, ci.StoreInfos[i].CommitID.Hash) {
+			t.Fatalf("StoreInfos[%d].CommitID.Hash mismatch", i)
+		}
+	}
+	if !bytes.Equal(got.Hash(), ci.Hash()) {
+		t.Fatal("round-tripped CommitInfo hashes differently than the original")
+	}
+}
+
+func TestCommitInfo_Unmarshal_RejectsBadMagicByte(t *testing.T) {
+	ci := sampleCommitInfo()
+	bz, err := ci.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	bz[0] = 0xFF
+
+	var got CommitInfo
+	if err := got.Unmarshal(bz); err == nil {
+		t.Fatal("expected error for invalid magic byte")
+	}
+}
+
+func TestCommitInfo_Unmarshal_RejectsUnsupportedVersion(t *testing.T) {
+	ci := sampleCommitInfo()
+	bz, err := ci.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	bz[1] = commitInfoVersion + 1
+
+	var got CommitInfo
+	if err := got.Unmarshal(bz); err == nil {
+		t.Fatal("expected error for unsupported encoding version")
+	}
+}
+
+func TestCommitInfo_Unmarshal_RejectsDuplicateStoreNames(t *testing.T) {
+	h := sha256.Sum256([]byte("store-a"))
+	ci := &CommitInfo{
+		Version: 1,
+		StoreInfos: []StoreInfo{
+			{Name: "a", CommitID: CommitID{Version: 1, Hash: h[:]}},
+			{Name: "a", CommitID: CommitID{Version: 1, Hash: h[:]}},
+		},
+	}
+
+	// Marshal sorts by name but has no notion of "duplicate" to reject, so
+	// its output still carries both "a" entries and exercises Unmarshal's own
+	// duplicate check.
+	bz, err := ci.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got CommitInfo
+	if err := got.Unmarshal(bz); err == nil {
+		t.Fatal("expected error for duplicate store names")
+	}
+}
+
+// TestCommitInfo_Unmarshal_RejectsUnsortedStoreNames is the canonical-
+// encoding guarantee CanonicalBytes promises to external light clients: two
+// byte strings encoding the same logical store set in different orders must
+// not both decode successfully, since they'd hash to different digests.
+func TestCommitInfo_Unmarshal_RejectsUnsortedStoreNames(t *testing.T) {
+	ci := sampleCommitInfo() // StoreInfos already sorted: "a", "b"
+	bz, err := ci.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	// Encode the two StoreInfos in swapped order to desync the encoded order
+	// from sorted order without going through Marshal (which would just
+	// re-sort them).
+	unsorted := &CommitInfo{
+		Version:    ci.Version,
+		Timestamp:  ci.Timestamp,
+		StoreInfos: []StoreInfo{ci.StoreInfos[1], ci.StoreInfos[0]},
+	}
+	var buf bytes.Buffer
+	buf.Write(bz[:commitInfoHeaderSize])
+	if err := EncodeUvarint(&buf, unsorted.Version); err != nil {
+		t.Fatalf("EncodeUvarint: %v", err)
+	}
+	if err := EncodeVarint(&buf, unsorted.Timestamp.UnixNano()); err != nil {
+		t.Fatalf("EncodeVarint: %v", err)
+	}
+	if err := EncodeUvarint(&buf, uint64(len(unsorted.StoreInfos))); err != nil {
+		t.Fatalf("EncodeUvarint: %v", err)
+	}
+	for _, si := range unsorted.StoreInfos {
+		if err := EncodeBytes(&buf, []byte(si.Name)); err != nil {
+			t.Fatalf("EncodeBytes: %v", err)
+		}
+		if err := EncodeBytes(&buf, si.CommitID.Hash); err != nil {
+			t.Fatalf("EncodeBytes: %v", err)
+		}
+	}
+
+	var got CommitInfo
+	if err := got.Unmarshal(buf.Bytes()); err == nil {
+		t.Fatal("expected error for out-of-order store names")
+	}
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/bits"
+
+	ics23 "github.com/cosmos/ics23/go"
+	"golang.org/x/crypto/sha3"
+)
+
+// Hasher abstracts the hash function used to build the commitment tree backing
+// CommitInfo.Hash and GetStoreProof. Chains that need commitment roots to be
+// cheap to verify outside of a CometBFT-style state machine (e.g. from an EVM
+// precompile or a zk circuit) can supply one instead of the SHA-256 default,
+// without forking the store.
+type Hasher interface {
+	// Hash returns the digest of data under this hash function.
+	Hash(data []byte) []byte
+	// HashOp returns the ics23 HashOp identifying this hash function, used to
+	// select a matching ics23.ProofSpec when verifying CommitmentOps produced
+	// against a tree built with this Hasher.
+	HashOp() ics23.HashOp
+}
+
+type sha256Hasher struct{}
+
+func (sha256Hasher) Hash(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+func (sha256Hasher) HashOp() ics23.HashOp {
+	return ics23.HashOp_SHA256
+}
+
+// DefaultHasher is the SHA-256 Hasher CommitInfo falls back to when none is
+// explicitly configured, matching CometBFT's simple merkle tree.
+var DefaultHasher Hasher = sha256Hasher{}
+
+type keccak256Hasher struct{}
+
+func (keccak256Hasher) Hash(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func (keccak256Hasher) HashOp() ics23.HashOp {
+	return ics23.HashOp_KECCAK
+}
+
+// Keccak256Hasher hashes with Keccak-256, matching Ethereum's hash function.
+// Chains that expose commitment roots to EVM precompiles can set it as a
+// CommitInfo's Hasher so proofs verify cheaply on that side.
+var Keccak256Hasher Hasher = keccak256Hasher{}
+
+// specForHasher returns the ics23.ProofSpec that verifies simple-merkle
+// CommitmentOps produced by GetStoreProof for the given Hasher. For the
+// default Hasher this is exactly ics23.TendermintSpec; otherwise it is the
+// same spec with the leaf/inner hash ops swapped to match h.
+func specForHasher(h Hasher) *ics23.ProofSpec {
+	if _, ok := h.(sha256Hasher); ok {
+		return ics23.TendermintSpec
+	}
+	return specForHashOp(h.HashOp())
+}
+
+// specForHashOp returns the ics23.ProofSpec that verifies simple-merkle
+// CommitmentOps whose leaf and inner nodes were hashed with op, reconstructing
+// the same spec specForHasher would build for a Hasher with that HashOp. It
+// lets callers that only have the HashOp on hand (e.g. after decoding a
+// MultiStoreProof off the wire) rebuild the matching spec without a concrete
+// Hasher.
+func specForHashOp(op ics23.HashOp) *ics23.ProofSpec {
+	if op == ics23.HashOp_SHA256 {
+		return ics23.TendermintSpec
+	}
+
+	spec := *ics23.TendermintSpec
+
+	leafOp := *spec.LeafSpec
+	leafOp.Hash = op
+	leafOp.PrehashValue = op
+	spec.LeafSpec = &leafOp
+
+	innerSpec := *spec.InnerSpec
+	innerSpec.Hash = op
+	spec.InnerSpec = &innerSpec
+
+	return &spec
+}
+
+// leafHash computes the simple-merkle leaf hash for a StoreInfo's (name, hash)
+// pair under hasher. For the default Hasher it delegates to LeafHash so the
+// default wire format and proofs are unchanged by this package.
+func leafHash(hasher Hasher, key, value []byte) ([]byte, error) {
+	if _, ok := hasher.(sha256Hasher); ok {
+		return LeafHash(key, value)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(0x00)
+	if err := EncodeBytes(&buf, key); err != nil {
+		return nil, err
+	}
+	// The value is prehashed, matching the PrehashValue op of the LeafSpec
+	// built by specForHashOp.
+	if err := EncodeBytes(&buf, hasher.Hash(value)); err != nil {
+		return nil, err
+	}
+	return hasher.Hash(buf.Bytes()), nil
+}
+
+// getSplitPoint returns the largest power of two strictly less than length,
+// the point CometBFT's simple merkle tree splits a slice of leaves at.
+func getSplitPoint(length int) int {
+	if length < 1 {
+		panic("trying to split a tree with size < 1")
+	}
+	k := 1 << uint(bits.Len(uint(length))-1)
+	if k == length {
+		k >>= 1
+	}
+	return k
+}
+
+func hashInnerNode(hasher Hasher, left, right []byte) []byte {
+	data := make([]byte, 0, 1+len(left)+len(right))
+	data = append(data, 0x01)
+	data = append(data, left...)
+	data = append(data, right...)
+	return hasher.Hash(data)
+}
+
+// convertCommitmentOp builds the CommitmentOp for a GetStoreProof result under
+// hasher. For the default Hasher it delegates to ConvertCommitmentOp so the
+// default CommitmentOp is unchanged by this package.
+func convertCommitmentOp(hasher Hasher, inners []*ics23.InnerOp, key, value []byte) CommitmentOp {
+	if _, ok := hasher.(sha256Hasher); ok {
+		return ConvertCommitmentOp(inners, key, value)
+	}
+
+	spec := specForHasher(hasher)
+	leaf := *spec.LeafSpec
+
+	op := NewSimpleMerkleCommitmentOp(key, &ics23.CommitmentProof{
+		Proof: &ics23.CommitmentProof_Exist{
+			Exist: &ics23.ExistenceProof{
+				Key:   key,
+				Value: value,
+				Leaf:  &leaf,
+				Path:  inners,
+			},
+		},
+	})
+	op.Spec = spec
+
+	return op
+}
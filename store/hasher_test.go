@@ -0,0 +1,57 @@
+package store
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	ics23 "github.com/cosmos/ics23/go"
+)
+
+func hasherTestCommitInfo(hasher Hasher, numStores int) *CommitInfo {
+	storeInfos := make([]StoreInfo, numStores)
+	for i := range storeInfos {
+		h := sha256.Sum256([]byte(fmt.Sprintf("store-hash-%d", i)))
+		storeInfos[i] = StoreInfo{
+			Name:     fmt.Sprintf("store%03d", i),
+			CommitID: CommitID{Version: 1, Hash: h[:]},
+		}
+	}
+	return &CommitInfo{Version: 1, StoreInfos: storeInfos, Hasher: hasher}
+}
+
+// TestGetStoreProof_Keccak256Hasher verifies that a CommitmentOp produced by
+// GetStoreProof against a non-default Hasher actually verifies, i.e. that the
+// proof's Leaf matches the LeafSpec of the Spec it is checked against. This
+// guards against regressing to a bare &ics23.LeafOp{} literal that omits
+// Length/Prefix and so fails ics23's CheckAgainstSpec unconditionally.
+func TestGetStoreProof_Keccak256Hasher(t *testing.T) {
+	ci := hasherTestCommitInfo(Keccak256Hasher, 5)
+	root := ci.Hash()
+
+	for _, si := range ci.StoreInfos {
+		_, proof, err := ci.GetStoreProof(si.Name)
+		if err != nil {
+			t.Fatalf("GetStoreProof(%s): %v", si.Name, err)
+		}
+		if !ics23.VerifyMembership(proof.Spec, root, proof.Proof, proof.Key, si.GetHash()) {
+			t.Fatalf("VerifyMembership failed for store %q under Keccak256Hasher", si.Name)
+		}
+	}
+}
+
+// TestGetStoreProof_Keccak256Hasher_WrongValueFails checks that the proof
+// above is actually discriminating, not just passing CheckAgainstSpec
+// vacuously.
+func TestGetStoreProof_Keccak256Hasher_WrongValueFails(t *testing.T) {
+	ci := hasherTestCommitInfo(Keccak256Hasher, 5)
+	root := ci.Hash()
+
+	_, proof, err := ci.GetStoreProof(ci.StoreInfos[0].Name)
+	if err != nil {
+		t.Fatalf("GetStoreProof: %v", err)
+	}
+	if ics23.VerifyMembership(proof.Spec, root, proof.Proof, proof.Key, []byte("wrong-hash")) {
+		t.Fatal("VerifyMembership unexpectedly succeeded with the wrong value")
+	}
+}
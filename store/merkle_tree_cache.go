@@ -0,0 +1,146 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	ics23 "github.com/cosmos/ics23/go"
+)
+
+// merkleNode is a node of a built (not just hashed) simple-merkle tree: unlike
+// computing a root hash alone, keeping the subtree nodes around lets
+// GetStoreProof walk straight to a proof path without re-hashing anything.
+type merkleNode struct {
+	hash  []byte
+	left  *merkleNode
+	right *merkleNode
+}
+
+// buildMerkleTree builds the full simple-merkle tree over leaves (already
+// leaf-hashed) using hasher. leaves must be non-empty.
+func buildMerkleTree(hasher Hasher, leaves [][]byte) *merkleNode {
+	if len(leaves) == 1 {
+		return &merkleNode{hash: leaves[0]}
+	}
+
+	split := getSplitPoint(len(leaves))
+	left := buildMerkleTree(hasher, leaves[:split])
+	right := buildMerkleTree(hasher, leaves[split:])
+
+	return &merkleNode{
+		hash:  hashInnerNode(hasher, left.hash, right.hash),
+		left:  left,
+		right: right,
+	}
+}
+
+// proofPath returns the ics23 InnerOp path proving leaf index out of
+// numLeaves total against n's hash, using the node hashes already computed by
+// buildMerkleTree instead of re-hashing any sibling subtree.
+func (n *merkleNode) proofPath(hasher Hasher, numLeaves, index int) []*ics23.InnerOp {
+	if numLeaves <= 1 {
+		return nil
+	}
+
+	split := getSplitPoint(numLeaves)
+	if index < split {
+		op := &ics23.InnerOp{Hash: hasher.HashOp(), Prefix: []byte{0x01}, Suffix: n.right.hash}
+		return append(n.left.proofPath(hasher, split, index), op)
+	}
+
+	op := &ics23.InnerOp{Hash: hasher.HashOp(), Prefix: append([]byte{0x01}, n.left.hash...)}
+	return append(n.right.proofPath(hasher, numLeaves-split, index-split), op)
+}
+
+// merkleTreeCache holds the simple-merkle tree built over a CommitInfo's
+// (sorted) StoreInfos, so Hash and repeated GetStoreProof calls for the same
+// set of StoreInfos don't rebuild it.
+type merkleTreeCache struct {
+	hasher Hasher
+	// storeInfos is the sorted snapshot the tree was built from; it is
+	// compared against the live CommitInfo.StoreInfos on every access to
+	// invalidate the cache if they diverge.
+	storeInfos []StoreInfo
+	root       *merkleNode
+	indexOf    map[string]int
+}
+
+func storeInfosEqual(a, b []StoreInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || !bytes.Equal(a[i].CommitID.Hash, b[i].CommitID.Hash) {
+			return false
+		}
+	}
+	return true
+}
+
+// cachedTree returns the merkleTreeCache for ci's current StoreInfos and
+// Hasher, sorting StoreInfos by name and (re)building the tree only if it
+// isn't already cached for that exact content. It holds ci.merkleTreeMu for
+// its duration, so concurrent callers on the same ci serialize here instead
+// of racing on the sort and on ci.merkleTree.
+func (ci *CommitInfo) cachedTree() (*merkleTreeCache, error) {
+	ci.merkleTreeMu.Lock()
+	defer ci.merkleTreeMu.Unlock()
+
+	sort.Slice(ci.StoreInfos, func(i, j int) bool {
+		return ci.StoreInfos[i].Name < ci.StoreInfos[j].Name
+	})
+
+	hasher := ci.hasher()
+	if ci.merkleTree != nil && ci.merkleTree.hasher == hasher && storeInfosEqual(ci.merkleTree.storeInfos, ci.StoreInfos) {
+		return ci.merkleTree, nil
+	}
+
+	leaves := make([][]byte, len(ci.StoreInfos))
+	indexOf := make(map[string]int, len(ci.StoreInfos))
+	snapshot := make([]StoreInfo, len(ci.StoreInfos))
+	for i, si := range ci.StoreInfos {
+		var err error
+		leaves[i], err = leafHash(hasher, []byte(si.Name), si.GetHash())
+		if err != nil {
+			return nil, err
+		}
+		indexOf[si.Name] = i
+		snapshot[i] = si
+	}
+
+	var root *merkleNode
+	if len(leaves) > 0 {
+		root = buildMerkleTree(hasher, leaves)
+	}
+
+	ci.merkleTree = &merkleTreeCache{
+		hasher:     hasher,
+		storeInfos: snapshot,
+		root:       root,
+		indexOf:    indexOf,
+	}
+	return ci.merkleTree, nil
+}
+
+// proof returns the root hash of the cached tree and the CommitmentOp proving
+// storeKey against it, defaulting to index 0 when storeKey isn't found (to
+// match the historical behavior of GetStoreProof). It reads only c's own
+// immutable snapshot, not the live CommitInfo, so it needs no synchronization
+// even though cachedTree may be rebuilding a fresh cache concurrently.
+func (c *merkleTreeCache) proof(storeKey string) ([]byte, *CommitmentOp, error) {
+	if c.root == nil {
+		return nil, nil, fmt.Errorf("commit info has no store infos")
+	}
+
+	index, ok := c.indexOf[storeKey]
+	if !ok {
+		index = 0
+	}
+
+	hasher := c.hasher
+	inners := c.root.proofPath(hasher, len(c.storeInfos), index)
+	commitmentOp := convertCommitmentOp(hasher, inners, []byte(storeKey), c.storeInfos[index].GetHash())
+
+	return c.root.hash, &commitmentOp, nil
+}
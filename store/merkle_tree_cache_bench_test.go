@@ -0,0 +1,50 @@
+package store
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+func benchCommitInfo(numStores int) *CommitInfo {
+	storeInfos := make([]StoreInfo, numStores)
+	for i := range storeInfos {
+		h := sha256.Sum256([]byte(fmt.Sprintf("store-hash-%d", i)))
+		storeInfos[i] = StoreInfo{
+			Name:     fmt.Sprintf("store%03d", i),
+			CommitID: CommitID{Version: 1, Hash: h[:]},
+		}
+	}
+	return &CommitInfo{Version: 1, StoreInfos: storeInfos}
+}
+
+func BenchmarkCommitInfo_Hash(b *testing.B) {
+	for _, numStores := range []int{4, 32, 128} {
+		b.Run(fmt.Sprintf("stores=%d", numStores), func(b *testing.B) {
+			ci := benchCommitInfo(numStores)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ci.Hash()
+			}
+		})
+	}
+}
+
+// BenchmarkCommitInfo_GetStoreProof_AllKeys proves every store key against the
+// same CommitInfo, the pattern the cache is meant to speed up: the tree is
+// built once and reused across all proofs instead of per key.
+func BenchmarkCommitInfo_GetStoreProof_AllKeys(b *testing.B) {
+	for _, numStores := range []int{4, 32, 128} {
+		b.Run(fmt.Sprintf("stores=%d", numStores), func(b *testing.B) {
+			ci := benchCommitInfo(numStores)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, si := range ci.StoreInfos {
+					if _, _, err := ci.GetStoreProof(si.Name); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	ics23 "github.com/cosmos/ics23/go"
+)
+
+// TestCachedTree_ParityWithProofFromByteSlices pins the cached merkleNode
+// tree built by buildMerkleTree/proofPath (used for every Hasher, including
+// the SHA-256 default) to the root hash and InnerOp path produced by the
+// repo's existing ProofFromByteSlices, for representative store counts. The
+// cache must not change the proofs essentially all existing chains rely on.
+func TestCachedTree_ParityWithProofFromByteSlices(t *testing.T) {
+	for _, numStores := range []int{1, 2, 3, 4, 5, 7, 8, 13, 32} {
+		t.Run(fmt.Sprintf("stores=%d", numStores), func(t *testing.T) {
+			ci := benchCommitInfo(numStores)
+
+			leaves := make([][]byte, numStores)
+			for i, si := range ci.StoreInfos {
+				h, err := LeafHash([]byte(si.Name), si.GetHash())
+				if err != nil {
+					t.Fatalf("LeafHash: %v", err)
+				}
+				leaves[i] = h
+			}
+
+			cachedRoot := ci.Hash()
+
+			for index, si := range ci.StoreInfos {
+				// ProofFromByteSlices hashes each level into the slice it is
+				// given, so every call gets its own copy of the leaves.
+				wantRoot, wantPath := ProofFromByteSlices(append([][]byte(nil), leaves...), index)
+				if !bytes.Equal(cachedRoot, wantRoot) {
+					t.Fatalf("cached tree root = %x, want %x", cachedRoot, wantRoot)
+				}
+
+				_, gotOp, err := ci.GetStoreProof(si.Name)
+				if err != nil {
+					t.Fatalf("GetStoreProof(%s): %v", si.Name, err)
+				}
+				gotPath := gotOp.Proof.GetExist().Path
+				if !reflect.DeepEqual(gotPath, wantPath) {
+					t.Fatalf("store %q: InnerOp path = %+v, want %+v", si.Name, gotPath, wantPath)
+				}
+
+				if !ics23.VerifyMembership(ics23.TendermintSpec, cachedRoot, gotOp.Proof, gotOp.Key, si.GetHash()) {
+					t.Fatalf("store %q: VerifyMembership failed", si.Name)
+				}
+			}
+		})
+	}
+}
+
+// TestCachedTree_ConcurrentGetStoreProof exercises the scenario this cache
+// exists for - consecutive queries for different keys at the same version -
+// under concurrency, the way real query handling hits it. Run with -race to
+// catch any data race on ci.merkleTree/ci.StoreInfos.
+func TestCachedTree_ConcurrentGetStoreProof(t *testing.T) {
+	ci := benchCommitInfo(32)
+	root := ci.Hash()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(ci.StoreInfos)*4)
+	for round := 0; round < 4; round++ {
+		for _, si := range ci.StoreInfos {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				gotRoot, op, err := ci.GetStoreProof(name)
+				if err != nil {
+					errs <- fmt.Errorf("GetStoreProof(%s): %w", name, err)
+					return
+				}
+				if !bytes.Equal(gotRoot, root) {
+					errs <- fmt.Errorf("GetStoreProof(%s) root = %x, want %x", name, gotRoot, root)
+					return
+				}
+				_ = op
+			}(si.Name)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestCachedTree_EmptyAndSingleStore(t *testing.T) {
+	empty := &CommitInfo{}
+	if got := empty.Hash(); got != nil {
+		t.Fatalf("Hash() of empty CommitInfo = %x, want nil", got)
+	}
+
+	h := sha256.Sum256([]byte("only-store"))
+	ci := &CommitInfo{Version: 1, StoreInfos: []StoreInfo{{Name: "only", CommitID: CommitID{Version: 1, Hash: h[:]}}}}
+	root, op, err := ci.GetStoreProof("only")
+	if err != nil {
+		t.Fatalf("GetStoreProof: %v", err)
+	}
+	if !ics23.VerifyMembership(ics23.TendermintSpec, root, op.Proof, op.Key, h[:]) {
+		t.Fatal("VerifyMembership failed for single-store tree")
+	}
+}
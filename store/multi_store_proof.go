@@ -0,0 +1,315 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+
+	ics23 "github.com/cosmos/ics23/go"
+)
+
+const (
+	// multiStoreProofMagic prefixes every Marshal'd MultiStoreProof, guarding
+	// against decoding an unrelated byte stream as a MultiStoreProof.
+	multiStoreProofMagic byte = 0xC2
+	// multiStoreProofVersion is the current MultiStoreProof encoding format
+	// version. Bumped to 2 to add the Membership discriminant and AppHashOp,
+	// neither of which round-tripped correctly under version 1.
+	multiStoreProofVersion byte = 2
+)
+
+// MultiStoreProof is a self-describing, two-level existence/nonexistence proof
+// bundle: an inner store-level ics23 proof (IAVL/SMT) that Key (with Value, for
+// membership) is committed in the store named StoreKey, and the outer
+// simple-merkle AppProof produced by CommitInfo.GetStoreProof proving that
+// store's root is committed in AppHash. It lets an IBC relayer or a
+// non-Cosmos verifier contract consume one opaque blob instead of two loose
+// CommitmentOps.
+type MultiStoreProof struct {
+	StoreKey string
+	Key      []byte
+	// Membership is true for a membership proof, false for a non-membership
+	// proof. It is the wire discriminant between the two: Value alone can't
+	// serve that role, since an empty (but non-nil, membership) value and a
+	// nil (non-membership) value are indistinguishable once round-tripped
+	// through a byte-slice wire encoding.
+	Membership bool
+	// Value is the proven value for a membership proof, and unused (must be
+	// nil) for a non-membership proof.
+	Value []byte
+	// StoreProofType is one of ProofOpIAVLCommitment or ProofOpSMTCommitment,
+	// selecting the ics23.ProofSpec StoreProof verifies against.
+	StoreProofType string
+	StoreProof     *ics23.CommitmentProof
+	AppProof       CommitmentOp
+	AppHash        []byte
+}
+
+// GetMembershipProof builds a MultiStoreProof proving that key/value is
+// committed under storeKey, given storeProof, the store-level ics23 proof for
+// key/value already obtained from that store, and storeProofType, the
+// ics23.ProofSpec (ProofOpIAVLCommitment or ProofOpSMTCommitment) storeProof
+// verifies against.
+func (ci *CommitInfo) GetMembershipProof(storeKey string, key, value []byte, storeProofType string, storeProof *ics23.CommitmentProof) (*MultiStoreProof, error) {
+	if _, err := specForStoreProofType(storeProofType); err != nil {
+		return nil, err
+	}
+
+	appHash, appProof, err := ci.GetStoreProof(storeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MultiStoreProof{
+		StoreKey:       storeKey,
+		Key:            key,
+		Membership:     true,
+		Value:          value,
+		StoreProofType: storeProofType,
+		StoreProof:     storeProof,
+		AppProof:       *appProof,
+		AppHash:        appHash,
+	}, nil
+}
+
+// GetNonMembershipProof builds a MultiStoreProof proving that key is absent
+// from storeKey, given storeProof, the store-level ics23 proof for key's
+// absence already obtained from that store, and storeProofType, the
+// ics23.ProofSpec (ProofOpIAVLCommitment or ProofOpSMTCommitment) storeProof
+// verifies against.
+func (ci *CommitInfo) GetNonMembershipProof(storeKey string, key []byte, storeProofType string, storeProof *ics23.CommitmentProof) (*MultiStoreProof, error) {
+	if _, err := specForStoreProofType(storeProofType); err != nil {
+		return nil, err
+	}
+
+	appHash, appProof, err := ci.GetStoreProof(storeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MultiStoreProof{
+		StoreKey:       storeKey,
+		Key:            key,
+		StoreProofType: storeProofType,
+		StoreProof:     storeProof,
+		AppProof:       *appProof,
+		AppHash:        appHash,
+	}, nil
+}
+
+// specForStoreProofType returns the ics23.ProofSpec matching storeProofType.
+func specForStoreProofType(storeProofType string) (*ics23.ProofSpec, error) {
+	switch storeProofType {
+	case ProofOpIAVLCommitment:
+		return ics23.IavlSpec, nil
+	case ProofOpSMTCommitment:
+		return ics23.SmtSpec, nil
+	default:
+		return nil, fmt.Errorf("unsupported multi-store proof store proof type: %s", storeProofType)
+	}
+}
+
+// VerifyMultiStore verifies msp in full: that msp.Key (and msp.Value, for a
+// membership proof) is committed in the store msp.StoreKey, and that the
+// store's root in turn is committed in the multi-store root hash root. This
+// chains the inner (store-level) and outer (app-level) verifications so a
+// relayer or verifier contract has a single entry point instead of running
+// two CommitmentOps by hand.
+func VerifyMultiStore(root []byte, msp *MultiStoreProof) error {
+	if msp == nil {
+		return fmt.Errorf("nil multi-store proof")
+	}
+	if !bytes.Equal(msp.AppProof.GetKey(), []byte(msp.StoreKey)) {
+		return fmt.Errorf("multi-store proof app proof key %q does not match claimed store key %q", msp.AppProof.GetKey(), msp.StoreKey)
+	}
+
+	spec, err := specForStoreProofType(msp.StoreProofType)
+	if err != nil {
+		return err
+	}
+
+	storeRoot, err := msp.StoreProof.Calculate()
+	if err != nil {
+		return fmt.Errorf("could not calculate inner store root for store %q: %w", msp.StoreKey, err)
+	}
+
+	if msp.Membership {
+		if !ics23.VerifyMembership(spec, storeRoot, msp.StoreProof, msp.Key, msp.Value) {
+			return fmt.Errorf("inner store proof did not verify existence of key %s in store %q", msp.Key, msp.StoreKey)
+		}
+	} else {
+		if !ics23.VerifyNonMembership(spec, storeRoot, msp.StoreProof, msp.Key) {
+			return fmt.Errorf("inner store proof did not verify absence of key %s in store %q", msp.Key, msp.StoreKey)
+		}
+	}
+
+	appHash, err := msp.AppProof.Run([][]byte{storeRoot})
+	if err != nil {
+		return fmt.Errorf("could not verify app-level proof for store %q: %w", msp.StoreKey, err)
+	}
+	if len(appHash) != 1 || !bytes.Equal(appHash[0], root) {
+		return fmt.Errorf("multi-store proof root does not match expected root")
+	}
+	if !bytes.Equal(appHash[0], msp.AppHash) {
+		return fmt.Errorf("multi-store proof app hash does not match the app hash it was generated against")
+	}
+
+	return nil
+}
+
+func (msp *MultiStoreProof) Marshal() ([]byte, error) {
+	storeProofBz, err := msp.StoreProof.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	appProofBz, err := msp.AppProof.Proof.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(multiStoreProofMagic)
+	buf.WriteByte(multiStoreProofVersion)
+
+	if err := EncodeBytes(&buf, []byte(msp.StoreKey)); err != nil {
+		return nil, err
+	}
+	if err := EncodeBytes(&buf, msp.Key); err != nil {
+		return nil, err
+	}
+	var membership byte
+	if msp.Membership {
+		membership = 1
+	}
+	buf.WriteByte(membership)
+	if err := EncodeBytes(&buf, msp.Value); err != nil {
+		return nil, err
+	}
+	if err := EncodeBytes(&buf, []byte(msp.StoreProofType)); err != nil {
+		return nil, err
+	}
+	if err := EncodeBytes(&buf, storeProofBz); err != nil {
+		return nil, err
+	}
+	if err := EncodeBytes(&buf, []byte(msp.AppProof.Type)); err != nil {
+		return nil, err
+	}
+	if err := EncodeBytes(&buf, msp.AppProof.Key); err != nil {
+		return nil, err
+	}
+	if err := EncodeBytes(&buf, appProofBz); err != nil {
+		return nil, err
+	}
+	if err := EncodeUvarint(&buf, uint64(msp.AppProof.Spec.GetLeafSpec().GetHash())); err != nil {
+		return nil, err
+	}
+	if err := EncodeBytes(&buf, msp.AppHash); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (msp *MultiStoreProof) Unmarshal(buf []byte) error {
+	if len(buf) < commitInfoHeaderSize {
+		return fmt.Errorf("multi-store proof bytes too short to contain header: got %d bytes", len(buf))
+	}
+	if buf[0] != multiStoreProofMagic {
+		return fmt.Errorf("invalid multi-store proof magic byte: got %#x, want %#x", buf[0], multiStoreProofMagic)
+	}
+	if buf[1] != multiStoreProofVersion {
+		return fmt.Errorf("unsupported multi-store proof encoding version: %d", buf[1])
+	}
+	buf = buf[commitInfoHeaderSize:]
+
+	storeKey, n, err := DecodeBytes(buf)
+	if err != nil {
+		return err
+	}
+	buf = buf[n:]
+	msp.StoreKey = string(storeKey)
+
+	key, n, err := DecodeBytes(buf)
+	if err != nil {
+		return err
+	}
+	buf = buf[n:]
+	msp.Key = key
+
+	if len(buf) < 1 {
+		return fmt.Errorf("multi-store proof bytes too short to contain membership flag")
+	}
+	membership := buf[0] != 0
+	buf = buf[1:]
+	msp.Membership = membership
+
+	value, n, err := DecodeBytes(buf)
+	if err != nil {
+		return err
+	}
+	buf = buf[n:]
+	if membership {
+		msp.Value = value
+	} else {
+		msp.Value = nil
+	}
+
+	storeProofType, n, err := DecodeBytes(buf)
+	if err != nil {
+		return err
+	}
+	buf = buf[n:]
+	msp.StoreProofType = string(storeProofType)
+
+	storeProofBz, n, err := DecodeBytes(buf)
+	if err != nil {
+		return err
+	}
+	buf = buf[n:]
+	msp.StoreProof = &ics23.CommitmentProof{}
+	if err := msp.StoreProof.Unmarshal(storeProofBz); err != nil {
+		return fmt.Errorf("could not unmarshal inner store proof: %w", err)
+	}
+
+	appProofType, n, err := DecodeBytes(buf)
+	if err != nil {
+		return err
+	}
+	buf = buf[n:]
+
+	appProofKey, n, err := DecodeBytes(buf)
+	if err != nil {
+		return err
+	}
+	buf = buf[n:]
+
+	appProofBz, n, err := DecodeBytes(buf)
+	if err != nil {
+		return err
+	}
+	buf = buf[n:]
+	appProof := &ics23.CommitmentProof{}
+	if err := appProof.Unmarshal(appProofBz); err != nil {
+		return fmt.Errorf("could not unmarshal app-level proof: %w", err)
+	}
+
+	appHashOp, n, err := DecodeUvarint(buf)
+	if err != nil {
+		return err
+	}
+	buf = buf[n:]
+
+	msp.AppProof = CommitmentOp{
+		Type:  string(appProofType),
+		Key:   appProofKey,
+		Spec:  specForHashOp(ics23.HashOp(appHashOp)),
+		Proof: appProof,
+	}
+
+	appHash, _, err := DecodeBytes(buf)
+	if err != nil {
+		return err
+	}
+	msp.AppHash = appHash
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"testing"
+
+	ics23 "github.com/cosmos/ics23/go"
+)
+
+// existenceProofForSpec builds a self-consistent single-entry ics23
+// ExistenceProof (no inner Path) for key/value under spec, by copying spec's
+// own LeafSpec into the proof's Leaf. The copied Leaf carries the spec's hash
+// and length ops, so no other encoding detail is needed, except for IavlSpec:
+// ics23 requires an IAVL leaf prefix to hold height, size and version varints,
+// so that prefix is set to height 0, size 1, version 1.
+func existenceProofForSpec(spec *ics23.ProofSpec, key, value []byte) *ics23.ExistenceProof {
+	leaf := *spec.LeafSpec
+	if spec == ics23.IavlSpec {
+		leaf.Prefix = []byte{0x00, 0x02, 0x02}
+	}
+	return &ics23.ExistenceProof{Key: key, Value: value, Leaf: &leaf}
+}
+
+func TestMultiStoreProof_MembershipRoundTrip(t *testing.T) {
+	const storeKey = "bank"
+	key, value := []byte("balance/addr1"), []byte("100")
+
+	exist := existenceProofForSpec(ics23.IavlSpec, key, value)
+	storeRoot, err := exist.Calculate()
+	if err != nil {
+		t.Fatalf("Calculate: %v", err)
+	}
+	storeProof := &ics23.CommitmentProof{Proof: &ics23.CommitmentProof_Exist{Exist: exist}}
+
+	ci := &CommitInfo{
+		Version:    1,
+		Hasher:     Keccak256Hasher,
+		StoreInfos: []StoreInfo{{Name: storeKey, CommitID: CommitID{Version: 1, Hash: storeRoot}}},
+	}
+
+	msp, err := ci.GetMembershipProof(storeKey, key, value, ProofOpIAVLCommitment, storeProof)
+	if err != nil {
+		t.Fatalf("GetMembershipProof: %v", err)
+	}
+	root := ci.Hash()
+
+	if err := VerifyMultiStore(root, msp); err != nil {
+		t.Fatalf("VerifyMultiStore before round trip: %v", err)
+	}
+
+	bz, err := msp.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got MultiStoreProof
+	if err := got.Unmarshal(bz); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if !got.Membership {
+		t.Fatal("Membership flag lost across round trip")
+	}
+	if string(got.Value) != string(value) {
+		t.Fatalf("Value = %q, want %q", got.Value, value)
+	}
+	if err := VerifyMultiStore(root, &got); err != nil {
+		t.Fatalf("VerifyMultiStore after round trip: %v", err)
+	}
+}
+
+func TestMultiStoreProof_NonMembershipRoundTrip(t *testing.T) {
+	const storeKey = "bank"
+	absentKey := []byte("balance/addr2")
+
+	// A neighbor proving absentKey falls after the only (lesser) key in the
+	// store is enough for ics23.VerifyNonMembership. SmtSpec compares keys by
+	// their SHA-256 hash; "balance/addr1" sorts before absentKey both as raw
+	// bytes and by hash.
+	left := existenceProofForSpec(ics23.SmtSpec, []byte("balance/addr1"), []byte("1"))
+	storeRoot, err := left.Calculate()
+	if err != nil {
+		t.Fatalf("Calculate: %v", err)
+	}
+	storeProof := &ics23.CommitmentProof{
+		Proof: &ics23.CommitmentProof_Nonexist{
+			Nonexist: &ics23.NonExistenceProof{Key: absentKey, Left: left},
+		},
+	}
+
+	ci := &CommitInfo{
+		Version:    1,
+		StoreInfos: []StoreInfo{{Name: storeKey, CommitID: CommitID{Version: 1, Hash: storeRoot}}},
+	}
+
+	msp, err := ci.GetNonMembershipProof(storeKey, absentKey, ProofOpSMTCommitment, storeProof)
+	if err != nil {
+		t.Fatalf("GetNonMembershipProof: %v", err)
+	}
+	root := ci.Hash()
+
+	if err := VerifyMultiStore(root, msp); err != nil {
+		t.Fatalf("VerifyMultiStore before round trip: %v", err)
+	}
+
+	bz, err := msp.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got MultiStoreProof
+	if err := got.Unmarshal(bz); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if got.Membership {
+		t.Fatal("Membership flag flipped to true across round trip")
+	}
+	// This is the regression this test guards: Value must stay nil, not
+	// round-trip into a non-nil empty slice that flips verification to
+	// VerifyMembership.
+	if got.Value != nil {
+		t.Fatalf("Value = %q, want nil", got.Value)
+	}
+	if err := VerifyMultiStore(root, &got); err != nil {
+		t.Fatalf("VerifyMultiStore after round trip: %v", err)
+	}
+}
+
+// TestVerifyMultiStore_RejectsStoreKeyMismatch guards against store-key
+// confusion: a proof claiming StoreKey "ibc" whose AppProof actually binds a
+// different store's ("bank") root to the app hash must not verify.
+func TestVerifyMultiStore_RejectsStoreKeyMismatch(t *testing.T) {
+	const storeKey = "bank"
+	key, value := []byte("balance/addr1"), []byte("100")
+
+	exist := existenceProofForSpec(ics23.IavlSpec, key, value)
+	storeRoot, err := exist.Calculate()
+	if err != nil {
+		t.Fatalf("Calculate: %v", err)
+	}
+	storeProof := &ics23.CommitmentProof{Proof: &ics23.CommitmentProof_Exist{Exist: exist}}
+
+	ci := &CommitInfo{
+		Version: 1,
+		StoreInfos: []StoreInfo{
+			{Name: storeKey, CommitID: CommitID{Version: 1, Hash: storeRoot}},
+			{Name: "ibc", CommitID: CommitID{Version: 1, Hash: []byte("some-other-root")}},
+		},
+	}
+
+	msp, err := ci.GetMembershipProof(storeKey, key, value, ProofOpIAVLCommitment, storeProof)
+	if err != nil {
+		t.Fatalf("GetMembershipProof: %v", err)
+	}
+	root := ci.Hash()
+	if err := VerifyMultiStore(root, msp); err != nil {
+		t.Fatalf("VerifyMultiStore on the honest proof: %v", err)
+	}
+
+	// Relabel the proof as proving a different store without changing
+	// AppProof, simulating an attacker re-tagging the "self-describing" blob.
+	msp.StoreKey = "ibc"
+	if err := VerifyMultiStore(root, msp); err == nil {
+		t.Fatal("VerifyMultiStore accepted a proof whose StoreKey doesn't match AppProof's key")
+	}
+}
+
+func TestGetMembershipProof_RejectsUnknownStoreProofType(t *testing.T) {
+	ci := &CommitInfo{
+		Version:    1,
+		StoreInfos: []StoreInfo{{Name: "bank", CommitID: CommitID{Version: 1, Hash: []byte("h")}}},
+	}
+	if _, err := ci.GetMembershipProof("bank", []byte("k"), []byte("v"), "bogus", &ics23.CommitmentProof{}); err == nil {
+		t.Fatal("expected error for unknown store proof type")
+	}
+}
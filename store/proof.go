@@ -1,6 +1,7 @@
 package store
 
 import (
+	"bytes"
 	"fmt"
 
 	cmtcrypto "github.com/cometbft/cometbft/proto/tendermint/crypto"
@@ -16,6 +17,13 @@ const (
 	ProofOpIAVLCommitment         = "ics23:iavl"
 	ProofOpSimpleMerkleCommitment = "ics23:simple"
 	ProofOpSMTCommitment          = "ics23:smt"
+
+	// Batch proof operation types wrap an ics23 BatchProof (optionally compressed)
+	// covering multiple keys against a single root, instead of a single
+	// existence/nonexistence proof.
+	ProofOpIAVLBatchCommitment         = "ics23:batch-iavl"
+	ProofOpSimpleMerkleBatchCommitment = "ics23:batch-simple"
+	ProofOpSMTBatchCommitment          = "ics23:batch-smt"
 )
 
 // CommitmentOp implements merkle.ProofOperator by wrapping an ics23 CommitmentProof.
@@ -59,6 +67,39 @@ func NewSMTCommitmentOp(key []byte, proof *ics23.CommitmentProof) CommitmentOp {
 	}
 }
 
+// NewIAVLBatchCommitmentOp returns a CommitmentOp that wraps an ics23 BatchProof
+// (or CompressedBatchProof) produced by an IAVL tree, proving a set of keys
+// against a single root rather than a single key.
+func NewIAVLBatchCommitmentOp(proof *ics23.CommitmentProof) CommitmentOp {
+	return CommitmentOp{
+		Type:  ProofOpIAVLBatchCommitment,
+		Spec:  ics23.IavlSpec,
+		Proof: proof,
+	}
+}
+
+// NewSimpleMerkleBatchCommitmentOp returns a CommitmentOp that wraps an ics23
+// BatchProof (or CompressedBatchProof) produced by a simple merkle tree, proving
+// a set of keys against a single root rather than a single key.
+func NewSimpleMerkleBatchCommitmentOp(proof *ics23.CommitmentProof) CommitmentOp {
+	return CommitmentOp{
+		Type:  ProofOpSimpleMerkleBatchCommitment,
+		Spec:  ics23.TendermintSpec,
+		Proof: proof,
+	}
+}
+
+// NewSMTBatchCommitmentOp returns a CommitmentOp that wraps an ics23 BatchProof
+// (or CompressedBatchProof) produced by an SMT, proving a set of keys against a
+// single root rather than a single key.
+func NewSMTBatchCommitmentOp(proof *ics23.CommitmentProof) CommitmentOp {
+	return CommitmentOp{
+		Type:  ProofOpSMTBatchCommitment,
+		Spec:  ics23.SmtSpec,
+		Proof: proof,
+	}
+}
+
 func (op CommitmentOp) GetKey() []byte {
 	return op.Key
 }
@@ -73,25 +114,45 @@ func (op CommitmentOp) GetKey() []byte {
 // the CommitmentRoot of the proof. If length 0 args is passed in, then CommitmentOp
 // will attempt to prove the absence of the key in the CommitmentOp and return the
 // CommitmentRoot of the proof.
+//
+// If the embedded CommitmentProof wraps a BatchProof (optionally compressed, e.g.
+// produced by NewIAVLBatchCommitmentOp), CommitmentOp instead proves a batch of
+// keys against a single root in one shot. A batch proof's entries may freely mix
+// existence and non-existence (e.g. an IBC multi-packet relay proving some
+// packets present and others already cleared in one proof); args must line up
+// with batch.Entries in order, contributing a [key, value] pair for each
+// existence entry and a single [key] for each non-existence entry.
 func (op CommitmentOp) Run(args [][]byte) ([][]byte, error) {
+	proof := op.Proof
+	if compressed := proof.GetCompressed(); compressed != nil {
+		decompressed, err := ics23.Decompress(proof)
+		if err != nil {
+			return nil, errorsmod.Wrapf(ErrInvalidProof, "could not decompress batch proof: %v", err)
+		}
+		proof = decompressed
+	}
+
 	// calculate root from proof
-	root, err := op.Proof.Calculate()
+	root, err := proof.Calculate()
 	if err != nil {
 		return nil, errorsmod.Wrapf(ErrInvalidProof, "could not calculate root for proof: %v", err)
 	}
 
-	// Only support an existence proof or nonexistence proof (batch proofs currently unsupported)
+	if batch := proof.GetBatch(); batch != nil {
+		return op.runBatch(proof, batch, root, args)
+	}
+
 	switch len(args) {
 	case 0:
 		// Args are nil, so we verify the absence of the key.
-		absent := ics23.VerifyNonMembership(op.Spec, root, op.Proof, op.Key)
+		absent := ics23.VerifyNonMembership(op.Spec, root, proof, op.Key)
 		if !absent {
 			return nil, errorsmod.Wrapf(ErrInvalidProof, "proof did not verify absence of key: %s", string(op.Key))
 		}
 
 	case 1:
 		// Args is length 1, verify existence of key with value args[0]
-		if !ics23.VerifyMembership(op.Spec, root, op.Proof, op.Key, args[0]) {
+		if !ics23.VerifyMembership(op.Spec, root, proof, op.Key, args[0]) {
 			return nil, errorsmod.Wrapf(ErrInvalidProof, "proof did not verify existence of key %s with given value %x", op.Key, args[0])
 		}
 
@@ -102,6 +163,59 @@ func (op CommitmentOp) Run(args [][]byte) ([][]byte, error) {
 	return [][]byte{root}, nil
 }
 
+// runBatch verifies a (decompressed) ics23 BatchProof against args, as documented
+// on Run. Entries are verified individually against root so existence and
+// non-existence entries can freely mix within a single batch.
+func (op CommitmentOp) runBatch(proof *ics23.CommitmentProof, batch *ics23.BatchProof, root []byte, args [][]byte) ([][]byte, error) {
+	if len(batch.Entries) == 0 {
+		return nil, errorsmod.Wrap(ErrInvalidProof, "batch proof has no entries")
+	}
+
+	pos := 0
+	for i, entry := range batch.Entries {
+		if exist := entry.GetExist(); exist != nil {
+			if pos+2 > len(args) {
+				return nil, errorsmod.Wrapf(ErrInvalidProof, "missing key/value args for existence entry %d", i)
+			}
+			key, value := args[pos], args[pos+1]
+			pos += 2
+
+			if !bytes.Equal(exist.Key, key) {
+				return nil, errorsmod.Wrapf(ErrInvalidProof, "batch entry %d key %s does not match arg key %s", i, exist.Key, key)
+			}
+			entryProof := &ics23.CommitmentProof{Proof: &ics23.CommitmentProof_Exist{Exist: exist}}
+			if !ics23.VerifyMembership(op.Spec, root, entryProof, key, value) {
+				return nil, errorsmod.Wrapf(ErrInvalidProof, "proof did not verify existence of batched key %s", key)
+			}
+			continue
+		}
+
+		nonexist := entry.GetNonexist()
+		if nonexist == nil {
+			return nil, errorsmod.Wrapf(ErrInvalidProof, "batch entry %d is neither an existence nor a non-existence proof", i)
+		}
+		if pos+1 > len(args) {
+			return nil, errorsmod.Wrapf(ErrInvalidProof, "missing key arg for non-existence entry %d", i)
+		}
+		key := args[pos]
+		pos++
+
+		if !bytes.Equal(nonexist.Key, key) {
+			return nil, errorsmod.Wrapf(ErrInvalidProof, "batch entry %d key %s does not match arg key %s", i, nonexist.Key, key)
+		}
+		entryProof := &ics23.CommitmentProof{Proof: &ics23.CommitmentProof_Nonexist{Nonexist: nonexist}}
+		if !ics23.VerifyNonMembership(op.Spec, root, entryProof, key) {
+			return nil, errorsmod.Wrapf(ErrInvalidProof, "proof did not verify absence of batched key %s", key)
+		}
+	}
+
+	if pos != len(args) {
+		return nil, errorsmod.Wrapf(ErrInvalidProof, "expected %d total args for batch proof, got %d", pos, len(args))
+	}
+
+	return [][]byte{root}, nil
+}
+
 // ProofOp implements ProofOperator interface and converts a CommitmentOp
 // into a merkle.ProofOp format that can later be decoded by CommitmentOpDecoder
 // back into a CommitmentOp for proof verification
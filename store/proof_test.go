@@ -0,0 +1,157 @@
+package store
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	ics23 "github.com/cosmos/ics23/go"
+)
+
+// proofTestCommitInfo builds a CommitInfo over a small simple-merkle tree
+// with the given (already sorted) store names, so real ExistenceProofs for
+// its entries can be combined into BatchProofs/NonExistenceProofs that
+// genuinely verify against a shared root, without hand-rolling tree math.
+func proofTestCommitInfo(names ...string) *CommitInfo {
+	storeInfos := make([]StoreInfo, len(names))
+	for i, name := range names {
+		h := sha256.Sum256([]byte(fmt.Sprintf("hash-%s", name)))
+		storeInfos[i] = StoreInfo{Name: name, CommitID: CommitID{Version: 1, Hash: h[:]}}
+	}
+	return &CommitInfo{Version: 1, StoreInfos: storeInfos}
+}
+
+func existProofFor(t *testing.T, ci *CommitInfo, name string) *ics23.ExistenceProof {
+	t.Helper()
+	_, op, err := ci.GetStoreProof(name)
+	if err != nil {
+		t.Fatalf("GetStoreProof(%s): %v", name, err)
+	}
+	exist := op.Proof.GetExist()
+	if exist == nil {
+		t.Fatalf("GetStoreProof(%s) did not return an existence proof", name)
+	}
+	return exist
+}
+
+func TestCommitmentOp_Run_BatchMembership(t *testing.T) {
+	ci := proofTestCommitInfo("a", "c", "e")
+	root := ci.Hash()
+
+	op := NewSimpleMerkleBatchCommitmentOp(&ics23.CommitmentProof{
+		Proof: &ics23.CommitmentProof_Batch{Batch: &ics23.BatchProof{
+			Entries: []*ics23.BatchEntry{
+				{Proof: &ics23.BatchEntry_Exist{Exist: existProofFor(t, ci, "a")}},
+				{Proof: &ics23.BatchEntry_Exist{Exist: existProofFor(t, ci, "e")}},
+			},
+		}},
+	})
+
+	args := [][]byte{
+		[]byte("a"), ci.GetStoreCommitID("a").Hash,
+		[]byte("e"), ci.GetStoreCommitID("e").Hash,
+	}
+
+	result, err := op.Run(args)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(result) != 1 || string(result[0]) != string(root) {
+		t.Fatalf("Run root = %x, want %x", result, root)
+	}
+
+	t.Run("wrong value", func(t *testing.T) {
+		bad := [][]byte{[]byte("a"), []byte("not-the-hash"), []byte("e"), ci.GetStoreCommitID("e").Hash}
+		if _, err := op.Run(bad); err == nil {
+			t.Fatal("expected error for wrong value")
+		}
+	})
+
+	t.Run("wrong arg count", func(t *testing.T) {
+		if _, err := op.Run(args[:3]); err == nil {
+			t.Fatal("expected error for wrong arg count")
+		}
+	})
+
+	t.Run("key order mismatch", func(t *testing.T) {
+		bad := [][]byte{[]byte("e"), ci.GetStoreCommitID("a").Hash, []byte("a"), ci.GetStoreCommitID("e").Hash}
+		if _, err := op.Run(bad); err == nil {
+			t.Fatal("expected error when args don't line up with entry order")
+		}
+	})
+}
+
+// TestCommitmentOp_Run_BatchMixed proves "a" present and "b" absent in a
+// single batch proof, the mixed-membership shape an IBC multi-packet relay
+// needs (e.g. some packets present, others already cleared).
+func TestCommitmentOp_Run_BatchMixed(t *testing.T) {
+	ci := proofTestCommitInfo("a", "c", "e")
+	root := ci.Hash()
+
+	op := NewSimpleMerkleBatchCommitmentOp(&ics23.CommitmentProof{
+		Proof: &ics23.CommitmentProof_Batch{Batch: &ics23.BatchProof{
+			Entries: []*ics23.BatchEntry{
+				{Proof: &ics23.BatchEntry_Exist{Exist: existProofFor(t, ci, "a")}},
+				{Proof: &ics23.BatchEntry_Nonexist{Nonexist: &ics23.NonExistenceProof{
+					Key:  []byte("b"),
+					Left: existProofFor(t, ci, "a"),
+					Right: existProofFor(t, ci, "c"),
+				}}},
+			},
+		}},
+	})
+
+	args := [][]byte{[]byte("a"), ci.GetStoreCommitID("a").Hash, []byte("b")}
+
+	result, err := op.Run(args)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if string(result[0]) != string(root) {
+		t.Fatalf("Run root = %x, want %x", result[0], root)
+	}
+
+	t.Run("non-existence key mismatch", func(t *testing.T) {
+		bad := [][]byte{[]byte("a"), ci.GetStoreCommitID("a").Hash, []byte("d")}
+		if _, err := op.Run(bad); err == nil {
+			t.Fatal("expected error when arg key doesn't match the batch entry's non-existence key")
+		}
+	})
+}
+
+func TestCommitmentOp_Run_BatchCompressed(t *testing.T) {
+	ci := proofTestCommitInfo("a", "c", "e")
+	root := ci.Hash()
+
+	full := &ics23.CommitmentProof{
+		Proof: &ics23.CommitmentProof_Batch{Batch: &ics23.BatchProof{
+			Entries: []*ics23.BatchEntry{
+				{Proof: &ics23.BatchEntry_Exist{Exist: existProofFor(t, ci, "a")}},
+			},
+		}},
+	}
+	compressed, err := ics23.Compress(full)
+	if err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+
+	op := NewSimpleMerkleBatchCommitmentOp(compressed)
+	args := [][]byte{[]byte("a"), ci.GetStoreCommitID("a").Hash}
+
+	result, err := op.Run(args)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if string(result[0]) != string(root) {
+		t.Fatalf("Run root = %x, want %x", result[0], root)
+	}
+}
+
+func TestCommitmentOp_Run_BatchNoEntries(t *testing.T) {
+	op := NewSimpleMerkleBatchCommitmentOp(&ics23.CommitmentProof{
+		Proof: &ics23.CommitmentProof_Batch{Batch: &ics23.BatchProof{}},
+	})
+	if _, err := op.Run(nil); err == nil {
+		t.Fatal("expected error for a batch proof with no entries")
+	}
+}